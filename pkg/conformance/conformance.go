@@ -0,0 +1,70 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package conformance holds the node types emitted for SMI conformance
+// statements (OBJECT-GROUP, NOTIFICATION-GROUP, MODULE-COMPLIANCE and
+// AGENT-CAPABILITIES) that gosmi/models has no equivalent for. Code generated
+// with mib2go's --include-conformance flag imports this package the same way
+// it imports gosmi/models and gosmi/types.
+package conformance
+
+import (
+	"github.com/sleepinggenius2/gosmi/models"
+	"github.com/sleepinggenius2/gosmi/types"
+)
+
+// GroupNode is an OBJECT-GROUP or NOTIFICATION-GROUP: a named list of the
+// objects or notifications that make it up.
+type GroupNode struct {
+	models.BaseNode
+	Members []models.ScalarNode
+}
+
+// Refinement is a single per-object MIN-ACCESS/SYNTAX override inside a
+// MODULE-COMPLIANCE statement.
+type Refinement struct {
+	Object    models.ScalarNode
+	MinAccess types.Access
+	Type      *models.Type
+}
+
+// ComplianceNode is a MODULE-COMPLIANCE statement: the groups a conformant
+// implementation must support, the groups it may optionally support, and any
+// per-object refinements of those requirements.
+type ComplianceNode struct {
+	models.BaseNode
+	Mandatory   []GroupNode
+	Optional    []GroupNode
+	Refinements []Refinement
+}
+
+// Variation is a single per-object SUPPORTS...VARIATION entry inside an
+// AGENT-CAPABILITIES statement.
+type Variation struct {
+	Object models.ScalarNode
+	Access types.Access
+}
+
+// CapabilitiesNode is an AGENT-CAPABILITIES statement: the variations an
+// agent implementation actually supports.
+type CapabilitiesNode struct {
+	models.BaseNode
+	Variations []Variation
+}
@@ -0,0 +1,88 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Unlike golden_test.go's hand-built ModuleData fixtures, this drives
+// buildGroupData/buildComplianceData/buildCapabilitiesData against a MIB
+// actually parsed by gosmi, so a change in what gosmi's API returns (for
+// better or worse) shows up here instead of being masked by fixtures that
+// never call these functions in the first place.
+package mib2go
+
+import (
+	"testing"
+
+	"github.com/sleepinggenius2/gosmi"
+	"github.com/sleepinggenius2/gosmi/models"
+	"github.com/sleepinggenius2/gosmi/types"
+)
+
+func TestBuildConformanceDataAgainstRealModule(t *testing.T) {
+	gosmi.Init()
+	defer gosmi.Exit()
+	gosmi.AppendPath("testdata/mibs")
+	if _, err := gosmi.LoadModule("MIB2GO-CONFORMANCE-TEST-MIB"); err != nil {
+		t.Fatalf("LoadModule: %v", err)
+	}
+
+	var group, compliance, capabilities *gosmi.SmiNode
+	for _, module := range gosmi.GetLoadedModules() {
+		if module.Name != "MIB2GO-CONFORMANCE-TEST-MIB" {
+			continue
+		}
+		for _, node := range module.GetNodes() {
+			node := node
+			switch node.Kind {
+			case types.NodeGroup:
+				group = &node
+			case types.NodeCompliance:
+				compliance = &node
+			case types.NodeCapabilities:
+				capabilities = &node
+			}
+		}
+	}
+	if group == nil || compliance == nil || capabilities == nil {
+		t.Fatalf("MIB2GO-CONFORMANCE-TEST-MIB did not load a group, compliance and capabilities node: group=%v compliance=%v capabilities=%v", group, compliance, capabilities)
+	}
+
+	// OBJECT-GROUP members come from Object.List, which gosmi does populate
+	// via AddElements, so this one genuinely works.
+	groupData := buildGroupData(*group)
+	if len(groupData.Members) != 1 || groupData.Members[0].VarName != formatNodeVarName("acmeTrapEnable") {
+		t.Errorf("buildGroupData = %+v, want one member acmeTrapEnableNode", groupData)
+	}
+
+	// MODULE-COMPLIANCE's mandatory/optional groups and refinements are
+	// never populated by gosmi (see the doc comment in conformance.go), even
+	// though this fixture's MIB text declares a mandatory group and a
+	// refinement. If a future gosmi version starts filling these in, this
+	// assertion should start failing and needs updating alongside it.
+	complianceData := buildComplianceData(*compliance, map[string]*models.Type{})
+	if len(complianceData.Mandatory) != 0 || len(complianceData.Optional) != 0 || len(complianceData.Refinements) != 0 {
+		t.Errorf("buildComplianceData = %+v, want all fields empty (gosmi limitation) - update conformance.go's doc comment and this test together if gosmi now carries this data", complianceData)
+	}
+
+	// AGENT-CAPABILITIES variations are never populated either, despite this
+	// fixture's MIB text declaring one.
+	capabilitiesData := buildCapabilitiesData(*capabilities)
+	if len(capabilitiesData.Variations) != 0 {
+		t.Errorf("buildCapabilitiesData = %+v, want no variations (gosmi limitation) - update conformance.go's doc comment and this test together if gosmi now carries this data", capabilitiesData)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mib2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the function map made available to every template,
+// built-in or user-supplied. Template authors can rely on these names when
+// writing a custom --template-file.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"formatNodeName":   formatNodeName,
+		"formatModuleName": formatModuleName,
+		"renderOID":        renderOID,
+		"baseType":         baseType,
+		"goLiteral":        goLiteral,
+		"markdownCell":     markdownCell,
+		"jsonString":       jsonString,
+	}
+}
+
+// renderOID returns the dotted-numeric OID string of a node, e.g.
+// "1.3.6.1.2.1.2.2.1.1".
+func renderOID(node NodeData) string {
+	return node.OidFormatted
+}
+
+// baseType returns the SMI base type name of a type, e.g. "OctetString".
+func baseType(t TypeData) string {
+	return t.BaseType
+}
+
+// goLiteral renders a value as a Go literal, the way the built-in "go"
+// template embeds Oid slices and named-number values in generated struct
+// literals.
+func goLiteral(v interface{}) string {
+	return fmt.Sprintf("%#v", v)
+}
+
+// jsonString renders a string as a JSON string literal, for templates (e.g.
+// "json") that must produce valid JSON. Unlike {{printf "%q" ...}} - Go's
+// strconv.Quote - this follows JSON's own string grammar, so it doesn't emit
+// escapes like \a, \v or \xHH that encoding/json.Unmarshal rejects.
+func jsonString(s string) string {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		// json.Marshal only fails on cyclic data or unsupported types,
+		// neither possible for a string.
+		panic(err)
+	}
+	return string(encoded)
+}
+
+// markdownCell makes a string safe to embed as a single Markdown table cell,
+// collapsing embedded newlines (SMI DESCRIPTION clauses are usually
+// multi-line) and escaping "|" so it can't be mistaken for a column
+// separator.
+func markdownCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
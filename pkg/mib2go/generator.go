@@ -0,0 +1,464 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package mib2go generates source from loaded MIB modules. It is the library
+// that backs the `mib2go generate` cobra command, but it is also meant to be
+// driven directly -- from a `//go:generate` line, from another program's
+// build tooling, or from tests -- without shelling out to the CLI.
+package mib2go
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sleepinggenius2/gosmi"
+	"github.com/sleepinggenius2/gosmi/models"
+	"github.com/sleepinggenius2/gosmi/types"
+)
+
+const allowedNodeKinds = types.NodeScalar | types.NodeTable | types.NodeRow | types.NodeColumn | types.NodeNotification
+
+// Default import paths for the packages the default "go" template's
+// generated files depend on. Options.ModelsImportPath/TypesImportPath
+// override these for forks that vendor gosmi under a different module path.
+const (
+	defaultModelsImportPath = "github.com/sleepinggenius2/gosmi/models"
+	defaultTypesImportPath  = "github.com/sleepinggenius2/gosmi/types"
+	conformanceImportPath   = "github.com/sleepinggenius2/mib2go/pkg/conformance"
+)
+
+// fileHeaderFormat is the generated-file header written ahead of the default
+// "go" template's output. The conformance import line is included only for
+// files that actually declare a conformance.*Node variable.
+const fileHeaderFormat = `// Code generated by mib2go. DO NOT EDIT.
+
+package %s
+
+import (
+	%q
+	%q
+%s)
+
+`
+
+// Options configures a Generator. The zero value is not valid: PackageName
+// and Modules must be set, and OutDir or Output must be set (Output takes
+// precedence when both are).
+type Options struct {
+	// PackageName is the Go package name emitted in generated file headers.
+	PackageName string
+
+	// Paths are MIB search paths, in gosmi.SetPath/AppendPath/PrependPath
+	// order. Callers are expected to have already resolved them (e.g.
+	// expanded "~").
+	Paths []string
+
+	// Modules are the MIB module names to load, in the order they should be
+	// loaded and generated.
+	Modules []string
+
+	// OutDir is the directory per-module files and types.go are written to.
+	// Ignored when Output is set.
+	OutDir string
+
+	// Output, when set, receives the generated source for every module and
+	// the shared types block as a single stream, instead of one file per
+	// module under OutDir.
+	Output io.Writer
+
+	// Template selects a built-in template by name (see
+	// BuiltinTemplateNames). Defaults to "go", the original hand-rolled Go
+	// emission. Ignored when TemplatePath is set.
+	Template string
+
+	// TemplatePath, when set, loads a user-supplied text/template file from
+	// disk instead of using a built-in template.
+	TemplatePath string
+
+	// IncludeConformance additionally emits OBJECT-GROUP/NOTIFICATION-GROUP,
+	// MODULE-COMPLIANCE and AGENT-CAPABILITIES nodes, importing
+	// github.com/sleepinggenius2/mib2go/pkg/conformance from generated Go
+	// files that need it.
+	IncludeConformance bool
+
+	// ModelsImportPath and TypesImportPath override the import path used for
+	// gosmi/models and gosmi/types in the default "go" template's generated
+	// header, and are passed through to every template as
+	// ModuleData.ModelsImportPath/TypesImportPath (TreeData for tree-scoped
+	// templates) for templates that build their own import block. Default to
+	// the upstream gosmi paths; set these when vendoring gosmi under a
+	// different module path.
+	ModelsImportPath string
+	TypesImportPath  string
+}
+
+// Generator generates source for a fixed set of MIB modules according to
+// Options.
+type Generator struct {
+	opts     Options
+	typesMap map[string]*models.Type
+}
+
+// New returns a Generator for the given Options.
+func New(opts Options) *Generator {
+	return &Generator{
+		opts:     opts,
+		typesMap: make(map[string]*models.Type),
+	}
+}
+
+// nodeKinds is the set of SMI node kinds to load for this Generator's
+// modules: allowedNodeKinds, plus conformanceNodeKinds when
+// Options.IncludeConformance is set.
+func (g *Generator) nodeKinds() types.NodeKind {
+	kinds := allowedNodeKinds
+	if g.opts.IncludeConformance {
+		kinds |= conformanceNodeKinds
+	}
+	return kinds
+}
+
+// modelsImportPath returns Options.ModelsImportPath, falling back to the
+// upstream gosmi/models path.
+func (g *Generator) modelsImportPath() string {
+	if g.opts.ModelsImportPath != "" {
+		return g.opts.ModelsImportPath
+	}
+	return defaultModelsImportPath
+}
+
+// typesImportPath returns Options.TypesImportPath, falling back to the
+// upstream gosmi/types path.
+func (g *Generator) typesImportPath() string {
+	if g.opts.TypesImportPath != "" {
+		return g.opts.TypesImportPath
+	}
+	return defaultTypesImportPath
+}
+
+// renderHeader renders fileHeaderFormat, including the conformance import
+// only when hasConformance is set.
+func (g *Generator) renderHeader(hasConformance bool) []byte {
+	conformanceImport := ""
+	if hasConformance {
+		conformanceImport = fmt.Sprintf("\t%q\n", conformanceImportPath)
+	}
+	return []byte(fmt.Sprintf(fileHeaderFormat, g.opts.PackageName, g.modelsImportPath(), g.typesImportPath(), conformanceImport))
+}
+
+// Generate loads every module in Options.Modules and renders them through the
+// selected template: once per module for module-scoped templates like the
+// default "go" one (which alone also gets a shared types.go/types block), or once for the whole
+// run for tree-scoped templates like "json". It initializes and tears down
+// its own gosmi state, so a Generator can be reused across multiple calls to
+// Generate.
+func (g *Generator) Generate(ctx context.Context) (err error) {
+	gosmi.Init()
+	defer gosmi.Exit()
+
+	for _, path := range g.opts.Paths {
+		if path == "" {
+			continue
+		}
+		switch path[0] {
+		case '+':
+			log.Println("Appending path", path[1:])
+			gosmi.AppendPath(path[1:])
+		case '-':
+			log.Println("Prepending path", path[1:])
+			gosmi.PrependPath(path[1:])
+		default:
+			log.Println("Setting path", path)
+			gosmi.SetPath(path)
+		}
+	}
+
+	for _, moduleName := range g.opts.Modules {
+		if _, err := gosmi.LoadModule(moduleName); err != nil {
+			return errors.Wrapf(err, "Loading module %s", moduleName)
+		}
+	}
+
+	tmpl, spec, err := loadTemplate(g.opts.Template, g.opts.TemplatePath)
+	if err != nil {
+		return err
+	}
+
+	isDefaultGoTemplate := g.opts.TemplatePath == "" && (g.opts.Template == "" || g.opts.Template == "go")
+
+	modules := gosmi.GetLoadedModules()
+	sortModules(modules)
+
+	if spec.scope == scopeTree {
+		return g.generateTree(ctx, modules, tmpl, spec)
+	}
+	return g.generateModules(ctx, modules, tmpl, spec, isDefaultGoTemplate)
+}
+
+func (g *Generator) generateModules(ctx context.Context, modules []gosmi.SmiModule, tmpl *template.Template, spec templateSpec, withSharedTypes bool) (err error) {
+	out := g.opts.Output
+	firstModule := true
+
+	// In combined-output mode every module shares one header, written only
+	// once, so it must already account for every module's needs.
+	combinedHasConformance := false
+	if out != nil && withSharedTypes {
+		for _, module := range modules {
+			if moduleNodesHaveKind(module, g.nodeKinds(), conformanceNodeKinds) {
+				combinedHasConformance = true
+				break
+			}
+		}
+	}
+
+	for _, module := range modules {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		nodes := module.GetNodes(g.nodeKinds())
+		sortNodes(nodes)
+		if len(nodes) == 0 {
+			log.Printf("Module %s: Skipping empty module\n", module.Name)
+			continue
+		}
+
+		data := buildModuleData(module, nodes, g.typesMap, g.modelsImportPath(), g.typesImportPath())
+
+		moduleBuf := &bytes.Buffer{}
+		if err := tmpl.Execute(moduleBuf, data); err != nil {
+			return errors.Wrapf(err, "Executing template for module %s", module.Name)
+		}
+
+		outFile := out
+		var closeFile *os.File
+		if outFile == nil {
+			filename := filepath.Join(g.opts.OutDir, strings.ToLower(module.Name)+spec.ext)
+			closeFile, err = os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return errors.Wrapf(err, "Opening file %s", filename)
+			}
+			defer closeFile.Close()
+			outFile = closeFile
+			log.Printf("Module %s: Outputting to %s\n", module.Name, filename)
+		}
+
+		var header []byte
+		if withSharedTypes && (out == nil || firstModule) {
+			hasConformance := combinedHasConformance
+			if out == nil {
+				hasConformance = moduleDataHasConformance(data)
+			}
+			header = g.renderHeader(hasConformance)
+		}
+		firstModule = false
+		if err := g.writeFile(outFile, moduleBuf.Bytes(), spec, header); err != nil {
+			return errors.Wrapf(err, "Writing module %s", module.Name)
+		}
+	}
+
+	if !withSharedTypes {
+		return nil
+	}
+	return g.writeSharedTypes(out)
+}
+
+func moduleNodesHaveKind(module gosmi.SmiModule, nodeKinds, mask types.NodeKind) bool {
+	for _, node := range module.GetNodes(nodeKinds) {
+		if node.Kind&mask != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func moduleDataHasConformance(data ModuleData) bool {
+	for _, node := range data.Nodes {
+		if node.Package == "conformance" {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Generator) writeSharedTypes(out io.Writer) (err error) {
+	typesTmpl, err := template.New("go-types").Funcs(TemplateFuncs()).Parse(goTypesTemplate)
+	if err != nil {
+		return errors.Wrap(err, "Parsing built-in types template")
+	}
+
+	typeKeys := make([]string, 0, len(g.typesMap))
+	for key := range g.typesMap {
+		typeKeys = append(typeKeys, key)
+	}
+	sort.Strings(typeKeys)
+
+	typesData := make([]TypeData, len(typeKeys))
+	for i, key := range typeKeys {
+		typesData[i] = *newTypeData(g.typesMap[key])
+	}
+
+	typesBuf := &bytes.Buffer{}
+	if err := typesTmpl.Execute(typesBuf, typesData); err != nil {
+		return errors.Wrap(err, "Executing types template")
+	}
+
+	outFile := out
+	var closeFile *os.File
+	if outFile == nil {
+		filename := filepath.Join(g.opts.OutDir, "types.go")
+		closeFile, err = os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return errors.Wrapf(err, "Opening file %s", filename)
+		}
+		defer closeFile.Close()
+		outFile = closeFile
+		log.Printf("Types: Outputting to %s\n", filename)
+	}
+
+	var header []byte
+	if out == nil {
+		// types.go only ever declares models.Type variables.
+		header = g.renderHeader(false)
+	}
+	return g.writeFile(outFile, typesBuf.Bytes(), templateSpec{ext: ".go"}, header)
+}
+
+func (g *Generator) generateTree(ctx context.Context, modules []gosmi.SmiModule, tmpl *template.Template, spec templateSpec) (err error) {
+	tree := TreeData{PackageName: g.opts.PackageName, ModelsImportPath: g.modelsImportPath(), TypesImportPath: g.typesImportPath()}
+	for _, module := range modules {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		nodes := module.GetNodes(g.nodeKinds())
+		sortNodes(nodes)
+		if len(nodes) == 0 {
+			log.Printf("Module %s: Skipping empty module\n", module.Name)
+			continue
+		}
+		tree.Modules = append(tree.Modules, buildModuleData(module, nodes, g.typesMap, g.modelsImportPath(), g.typesImportPath()))
+	}
+
+	typeKeys := make([]string, 0, len(g.typesMap))
+	for key := range g.typesMap {
+		typeKeys = append(typeKeys, key)
+	}
+	sort.Strings(typeKeys)
+	for _, key := range typeKeys {
+		tree.Types = append(tree.Types, *newTypeData(g.typesMap[key]))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, tree); err != nil {
+		return errors.Wrap(err, "Executing template")
+	}
+
+	out := g.opts.Output
+	var closeFile *os.File
+	if out == nil {
+		filename := filepath.Join(g.opts.OutDir, "mib"+spec.ext)
+		closeFile, err = os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return errors.Wrapf(err, "Opening file %s", filename)
+		}
+		defer closeFile.Close()
+		out = closeFile
+		log.Printf("Outputting to %s\n", filename)
+	}
+
+	return g.writeFile(out, buf.Bytes(), spec, nil)
+}
+
+// sortModules orders modules by name, so repeated runs over the same set of
+// modules produce byte-identical output regardless of gosmi.GetLoadedModules'
+// iteration order.
+func sortModules(modules []gosmi.SmiModule) {
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+}
+
+// sortNodes orders nodes by OID, falling back to name for nodes that share
+// an OID, so repeated runs over the same MIBs
+// produce byte-identical output regardless of gosmi's internal iteration
+// order.
+func sortNodes(nodes []gosmi.SmiNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Oid.Equals(nodes[j].Oid) {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].Oid.Before(nodes[j].Oid)
+	})
+}
+
+func formatModuleName(moduleName string) (formattedName string) {
+	parts := strings.Split(moduleName, "-")
+	for _, part := range parts {
+		formattedName += strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+	}
+	return
+}
+
+func formatModuleVarName(moduleName string) (formattedName string) {
+	formattedModuleName := formatModuleName(moduleName)
+	return strings.ToLower(formattedModuleName[:1]) + formattedModuleName[1:] + "Module"
+}
+
+func formatNodeName(nodeName string) (formattedName string) {
+	return strings.ToUpper(nodeName[:1]) + nodeName[1:]
+}
+
+func formatNodeVarName(nodeName string) (formattedName string) {
+	return strings.ToLower(nodeName[:1]) + nodeName[1:] + "Node"
+}
+
+// writeFile runs go/format over Go-flavored output before writing it, and
+// optionally prepends header (see renderHeader), the generated-file header
+// used by the default "go" template.
+func (g *Generator) writeFile(out io.Writer, b []byte, spec templateSpec, header []byte) error {
+	source := b
+	if spec.ext == ".go" {
+		formatted, err := format.Source(b)
+		if err != nil {
+			return errors.Wrap(err, "Generating formatted source")
+		}
+		source = formatted
+	}
+
+	if len(header) > 0 {
+		if _, err := out.Write(header); err != nil {
+			return errors.Wrap(err, "Writing file header")
+		}
+	}
+
+	if _, err := out.Write(source); err != nil {
+		return errors.Wrap(err, "Writing file")
+	}
+
+	return nil
+}
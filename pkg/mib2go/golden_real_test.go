@@ -0,0 +1,89 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Unlike golden_test.go's hand-built ModuleData fixtures, this drives the
+// public Generate(ctx) entrypoint against MIBs gosmi actually loads and
+// parses from testdata/mibs: MIB2GO-CONFORMANCE-TEST-MIB (OBJECT-GROUP,
+// MODULE-COMPLIANCE, AGENT-CAPABILITIES) and MIB2GO-TYPES-TEST-MIB (a
+// shared Enumeration TEXTUAL-CONVENTION and a shared range-restricted
+// one). Generating both in one run also exercises the bookkeeping
+// Generate's own pipeline does around the fixtures (module iteration
+// order, generateModules' combinedHasConformance/firstModule header
+// handling, and buildModuleData/buildGroupData/buildComplianceData/
+// buildCapabilitiesData/newTypeData's Enum and Ranges branches run
+// through writeSharedTypes), none of which golden_test.go's hand-built
+// ModuleData values touch.
+package mib2go
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoldenGoTemplateAgainstRealModule(t *testing.T) {
+	modules := []string{"MIB2GO-CONFORMANCE-TEST-MIB", "MIB2GO-TYPES-TEST-MIB"}
+
+	var buf bytes.Buffer
+	g := New(Options{
+		PackageName:        "mibs",
+		Paths:              []string{"testdata/mibs"},
+		Modules:            modules,
+		Output:             &buf,
+		IncludeConformance: true,
+	})
+	if err := g.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	got := buf.Bytes()
+
+	var again bytes.Buffer
+	g2 := New(Options{
+		PackageName:        "mibs",
+		Paths:              []string{"testdata/mibs"},
+		Modules:            modules,
+		Output:             &again,
+		IncludeConformance: true,
+	})
+	if err := g2.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate (second run): %v", err)
+	}
+	if !bytes.Equal(got, again.Bytes()) {
+		t.Fatalf("Generate output is not deterministic across repeated runs")
+	}
+
+	goldenPath := filepath.Join("testdata", "golden", "mib2go_real_mibs.go.golden")
+	if *updateGolden {
+		if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("output does not match %s (run with -update to refresh it)\n--- got ---\n%s\n--- want ---\n%s", goldenPath, got, want)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Golden-file tests for the default "go" template, locking its output
+// byte-for-byte for a handful of representative module shapes. The fixtures
+// below are hand-built ModuleData values standing in for modules loaded
+// through gosmi, so that SNMPv2-MIB's and IF-MIB's actual shapes (which
+// this repo has no need to vendor in full) can still be modeled: a plain
+// scalar plus a notification, and a table/row/column with an index and a
+// shared enum type, respectively. They exercise the template/formatting
+// layer (renderGoModule) in isolation. golden_real_test.go complements
+// these by driving the same vendor-MIB shape through the public
+// Generate(ctx) entrypoint end to end, against a MIB gosmi actually parses.
+package mib2go
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sleepinggenius2/gosmi/types"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+func snmpv2MibFixture() ModuleData {
+	sysDescr := NodeData{
+		Name:         "sysDescr",
+		GoName:       formatNodeName("sysDescr"),
+		VarName:      formatNodeVarName("sysDescr"),
+		Kind:         types.NodeScalar.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 2, 1, 1, 1, 0},
+		OidFormatted: "1.3.6.1.2.1.1.1.0",
+		OidLen:       9,
+		Package:      "models",
+		Type: &TypeData{
+			Name:     "OctetString",
+			GoName:   formatNodeName("OctetString"),
+			BaseType: types.BaseTypeOctetString.String(),
+		},
+	}
+	coldStart := NodeData{
+		Name:         "coldStart",
+		GoName:       formatNodeName("coldStart"),
+		VarName:      formatNodeVarName("coldStart"),
+		Kind:         types.NodeNotification.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 6, 3, 1, 1, 5, 1},
+		OidFormatted: "1.3.6.1.6.3.1.1.5.1",
+		OidLen:       10,
+		Package:      "models",
+		Objects: []NotificationObjectData{
+			{VarName: sysDescr.VarName, IsPlain: true},
+		},
+	}
+
+	return ModuleData{
+		Name:    "SNMPv2-MIB",
+		GoName:  formatModuleName("SNMPv2-MIB"),
+		VarName: formatModuleVarName("SNMPv2-MIB"),
+		Nodes:   []NodeData{sysDescr, coldStart},
+	}
+}
+
+func ifMibFixture() ModuleData {
+	ifIndex := NodeData{
+		Name:         "ifIndex",
+		GoName:       formatNodeName("ifIndex"),
+		VarName:      formatNodeVarName("ifIndex"),
+		Kind:         types.NodeColumn.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 2, 1, 2, 2, 1, 1},
+		OidFormatted: "1.3.6.1.2.1.2.2.1.1",
+		OidLen:       10,
+		Package:      "models",
+		Type: &TypeData{
+			Name:     "Integer32",
+			GoName:   formatNodeName("Integer32"),
+			BaseType: types.BaseTypeInteger32.String(),
+		},
+	}
+	ifAdminStatus := NodeData{
+		Name:         "ifAdminStatus",
+		GoName:       formatNodeName("ifAdminStatus"),
+		VarName:      formatNodeVarName("ifAdminStatus"),
+		Kind:         types.NodeColumn.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 2, 1, 2, 2, 1, 7},
+		OidFormatted: "1.3.6.1.2.1.2.2.1.7",
+		OidLen:       10,
+		Package:      "models",
+		Type: &TypeData{
+			Name:   "IfAdminStatus",
+			GoName: formatNodeName("IfAdminStatus"),
+			Shared: true,
+		},
+	}
+	ifEntry := NodeData{
+		Name:         "ifEntry",
+		GoName:       formatNodeName("ifEntry"),
+		VarName:      formatNodeVarName("ifEntry"),
+		Kind:         types.NodeRow.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 2, 1, 2, 2, 1},
+		OidFormatted: "1.3.6.1.2.1.2.2.1",
+		OidLen:       9,
+		Package:      "models",
+		Columns:      []string{ifIndex.VarName, ifAdminStatus.VarName},
+		Index:        []string{ifIndex.VarName},
+	}
+	ifTable := NodeData{
+		Name:         "ifTable",
+		GoName:       formatNodeName("ifTable"),
+		VarName:      formatNodeVarName("ifTable"),
+		Kind:         types.NodeTable.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 2, 1, 2, 2},
+		OidFormatted: "1.3.6.1.2.1.2.2",
+		OidLen:       8,
+		Package:      "models",
+		RowVarName:   ifEntry.VarName,
+	}
+
+	return ModuleData{
+		Name:    "IF-MIB",
+		GoName:  formatModuleName("IF-MIB"),
+		VarName: formatModuleVarName("IF-MIB"),
+		Nodes:   []NodeData{ifTable, ifEntry, ifIndex, ifAdminStatus},
+	}
+}
+
+func vendorConformanceMibFixture() ModuleData {
+	acmeTrapEnable := NodeData{
+		Name:         "acmeTrapEnable",
+		GoName:       formatNodeName("acmeTrapEnable"),
+		VarName:      formatNodeVarName("acmeTrapEnable"),
+		Kind:         types.NodeScalar.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 4, 1, 9999, 1, 1, 0},
+		OidFormatted: "1.3.6.1.4.1.9999.1.1.0",
+		OidLen:       10,
+		Package:      "models",
+		Type: &TypeData{
+			Name:   "AcmeFeatureBits",
+			GoName: formatNodeName("AcmeFeatureBits"),
+			Shared: true,
+		},
+	}
+	acmeGroup := NodeData{
+		Name:         "acmeBasicGroup",
+		GoName:       formatNodeName("acmeBasicGroup"),
+		VarName:      formatNodeVarName("acmeBasicGroup"),
+		Kind:         types.NodeGroup.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 4, 1, 9999, 2, 1},
+		OidFormatted: "1.3.6.1.4.1.9999.2.1",
+		OidLen:       9,
+		Package:      "conformance",
+		Group: &GroupData{
+			Members: []NotificationObjectData{
+				{VarName: acmeTrapEnable.VarName, IsPlain: true},
+			},
+		},
+	}
+	acmeCompliance := NodeData{
+		Name:         "acmeCompliance",
+		GoName:       formatNodeName("acmeCompliance"),
+		VarName:      formatNodeVarName("acmeCompliance"),
+		Kind:         types.NodeCompliance.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 4, 1, 9999, 2, 2},
+		OidFormatted: "1.3.6.1.4.1.9999.2.2",
+		OidLen:       9,
+		Package:      "conformance",
+		Compliance: &ComplianceData{
+			Mandatory: []string{acmeGroup.VarName},
+			Refinements: []RefinementData{
+				{
+					Object:    NotificationObjectData{VarName: acmeTrapEnable.VarName, IsPlain: true},
+					MinAccess: types.AccessReadOnly.String(),
+				},
+			},
+		},
+	}
+	acmeCapabilities := NodeData{
+		Name:         "acmeAgentCaps",
+		GoName:       formatNodeName("acmeAgentCaps"),
+		VarName:      formatNodeVarName("acmeAgentCaps"),
+		Kind:         types.NodeCapabilities.String(),
+		Oid:          types.Oid{1, 3, 6, 1, 4, 1, 9999, 3, 1},
+		OidFormatted: "1.3.6.1.4.1.9999.3.1",
+		OidLen:       9,
+		Package:      "conformance",
+		Capabilities: &CapabilitiesData{
+			Variations: []VariationData{
+				{Object: NotificationObjectData{VarName: acmeTrapEnable.VarName, IsPlain: true}, Access: types.AccessReadWrite.String()},
+			},
+		},
+	}
+
+	return ModuleData{
+		Name:    "ACME-MIB",
+		GoName:  formatModuleName("ACME-MIB"),
+		VarName: formatModuleVarName("ACME-MIB"),
+		Nodes:   []NodeData{acmeTrapEnable, acmeGroup, acmeCompliance, acmeCapabilities},
+	}
+}
+
+// renderGoModule runs a ModuleData fixture through the built-in "go"
+// template and the same header/formatting pipeline Generator.generateModules
+// uses, so the golden file matches actual `mib2go generate` output byte for
+// byte.
+func renderGoModule(t *testing.T, data ModuleData, includeConformance bool) []byte {
+	t.Helper()
+
+	tmpl, _, err := loadTemplate("go", "")
+	if err != nil {
+		t.Fatalf("loadTemplate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("format.Source: %v\n%s", err, buf.String())
+	}
+
+	g := New(Options{PackageName: "mibs", IncludeConformance: includeConformance})
+	header := g.renderHeader(includeConformance)
+
+	return append(header, formatted...)
+}
+
+func TestGoldenGoTemplate(t *testing.T) {
+	tests := []struct {
+		name               string
+		data               ModuleData
+		includeConformance bool
+	}{
+		{name: "snmpv2_mib", data: snmpv2MibFixture()},
+		{name: "if_mib", data: ifMibFixture()},
+		{name: "vendor_conformance_mib", data: vendorConformanceMibFixture(), includeConformance: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderGoModule(t, tt.data, tt.includeConformance)
+
+			again := renderGoModule(t, tt.data, tt.includeConformance)
+			if !bytes.Equal(got, again) {
+				t.Fatalf("template output is not deterministic across repeated executions")
+			}
+
+			goldenPath := filepath.Join("testdata", "golden", tt.name+".go.golden")
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("reading golden file: %v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("output does not match %s (run with -update to refresh it)\n--- got ---\n%s\n--- want ---\n%s", goldenPath, got, want)
+			}
+		})
+	}
+}
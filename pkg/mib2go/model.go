@@ -0,0 +1,262 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mib2go
+
+import (
+	"sort"
+
+	"github.com/sleepinggenius2/gosmi"
+	"github.com/sleepinggenius2/gosmi/models"
+	"github.com/sleepinggenius2/gosmi/types"
+)
+
+// ModuleData is the template-facing representation of a single loaded MIB
+// module. It is built once per module, independent of any output format, so
+// that every template (built-in or user-supplied) works from the same data.
+type ModuleData struct {
+	Name    string // raw MIB module name, e.g. "IF-MIB"
+	GoName  string // formatted identifier, e.g. "IfMib"
+	VarName string // e.g. "ifMibModule"
+	Nodes   []NodeData
+
+	// ModelsImportPath and TypesImportPath are Options.ModelsImportPath and
+	// Options.TypesImportPath (resolved to their defaults when unset), so a
+	// user-supplied template can build its own import block instead of
+	// relying on the default "go" template's generated-file header.
+	ModelsImportPath string
+	TypesImportPath  string
+}
+
+// NodeData is the template-facing representation of one SMI node
+// (scalar, table, row, column or notification, plus group, compliance or
+// capabilities when conformance nodes are included).
+type NodeData struct {
+	Name         string
+	GoName       string
+	VarName      string
+	Kind         string
+	Description  string
+	Oid          types.Oid
+	OidFormatted string
+	OidLen       int
+
+	// Package is the import alias the node's "<Kind>Node" struct comes from:
+	// "models" for the kinds gosmi/models defines, "conformance" for the
+	// group/compliance/capabilities kinds mib2go defines itself.
+	Package string
+
+	// Type is set for scalar and column nodes.
+	Type *TypeData
+
+	// RowVarName is set for table nodes.
+	RowVarName string
+
+	// Columns and Index are set for row nodes, as the VarName of each
+	// referenced column.
+	Columns []string
+	Index   []string
+
+	// Objects is set for notification nodes, as the VarName of each
+	// referenced object, together with whether it needs a ScalarNode
+	// conversion (i.e. it isn't already a plain scalar).
+	Objects []NotificationObjectData
+
+	// Group is set for OBJECT-GROUP/NOTIFICATION-GROUP nodes.
+	Group *GroupData
+
+	// Compliance is set for MODULE-COMPLIANCE nodes.
+	Compliance *ComplianceData
+
+	// Capabilities is set for AGENT-CAPABILITIES nodes.
+	Capabilities *CapabilitiesData
+}
+
+// NotificationObjectData is one entry of a notification's object list.
+type NotificationObjectData struct {
+	VarName string
+	IsPlain bool // true when the object is already a models.ScalarNode
+}
+
+// TypeData is the template-facing representation of a shared or inline SMI
+// type.
+type TypeData struct {
+	Name     string
+	GoName   string
+	BaseType string
+	Format   string
+	Units    string
+	Enum     *EnumData
+	Ranges   []RangeData
+
+	// Shared is true when this type is emitted once as a package-level
+	// variable in types.go and referenced by name, rather than inlined on
+	// every node that uses it.
+	Shared bool
+}
+
+// EnumData describes an enumeration or bits type.
+type EnumData struct {
+	BaseType string
+	Values   []NamedNumberData
+}
+
+// NamedNumberData is a single named enum/bits value.
+type NamedNumberData struct {
+	Name  string
+	Value int64
+}
+
+// RangeData is a single size/value range restriction on a type.
+type RangeData struct {
+	BaseType string
+	MinValue int64
+	MaxValue int64
+}
+
+// inlineTypeNames are the SMI base type names that are always emitted inline
+// on their node rather than pulled out into a shared types.go variable.
+var inlineTypeNames = map[string]bool{
+	"Integer32":        true,
+	"OctetString":      true,
+	"ObjectIdentifier": true,
+	"Unsigned32":       true,
+	"Integer64":        true,
+	"Unsigned64":       true,
+	"Enumeration":      true,
+	"Bits":             true,
+}
+
+func buildModuleData(module gosmi.SmiModule, nodes []gosmi.SmiNode, typesMap map[string]*models.Type, modelsImportPath, typesImportPath string) ModuleData {
+	data := ModuleData{
+		Name:             module.Name,
+		GoName:           formatModuleName(module.Name),
+		VarName:          formatModuleVarName(module.Name),
+		Nodes:            make([]NodeData, len(nodes)),
+		ModelsImportPath: modelsImportPath,
+		TypesImportPath:  typesImportPath,
+	}
+	for i, node := range nodes {
+		data.Nodes[i] = buildNodeData(node, typesMap)
+	}
+	return data
+}
+
+func buildNodeData(node gosmi.SmiNode, typesMap map[string]*models.Type) NodeData {
+	oid := node.Oid
+	oidFormatted := node.RenderNumeric()
+	oidLen := node.OidLen
+	if node.Kind == types.NodeScalar {
+		oid = append(oid, 0)
+		oidFormatted += ".0"
+		oidLen++
+	}
+
+	data := NodeData{
+		Name:         node.Name,
+		GoName:       formatNodeName(node.Name),
+		VarName:      formatNodeVarName(node.Name),
+		Kind:         node.Kind.String(),
+		Description:  node.Description,
+		Oid:          oid,
+		OidFormatted: oidFormatted,
+		OidLen:       oidLen,
+		Package:      "models",
+	}
+
+	switch node.Kind {
+	case types.NodeColumn, types.NodeScalar:
+		data.Type = buildTypeData(node.Type, typesMap)
+	case types.NodeTable:
+		data.RowVarName = formatNodeVarName(node.GetRow().Name)
+	case types.NodeRow:
+		_, columnOrder := node.GetColumns()
+		for _, column := range columnOrder {
+			data.Columns = append(data.Columns, formatNodeVarName(column))
+		}
+		for _, index := range node.GetIndex() {
+			data.Index = append(data.Index, formatNodeVarName(index.Name))
+		}
+	case types.NodeGroup:
+		data.Package = "conformance"
+		data.Group = buildGroupData(node)
+	case types.NodeCompliance:
+		data.Package = "conformance"
+		data.Compliance = buildComplianceData(node, typesMap)
+	case types.NodeCapabilities:
+		data.Package = "conformance"
+		data.Capabilities = buildCapabilitiesData(node)
+	case types.NodeNotification:
+		for _, object := range node.GetNotificationObjects() {
+			data.Objects = append(data.Objects, NotificationObjectData{
+				VarName: formatNodeVarName(object.Name),
+				IsPlain: object.Kind == types.NodeScalar,
+			})
+		}
+	}
+
+	return data
+}
+
+// buildTypeData converts an SMI type into its template representation. If
+// the type isn't one of the always-inline base types, it is also registered
+// in typesMap so a single shared variable is emitted once in types.go.
+func buildTypeData(t *models.Type, typesMap map[string]*models.Type) *TypeData {
+	if t == nil {
+		return nil
+	}
+	data := newTypeData(t)
+	if !inlineTypeNames[t.Name] {
+		data.Shared = true
+		if _, ok := typesMap[t.Name]; !ok {
+			typesMap[t.Name] = t
+		}
+	}
+	return data
+}
+
+func newTypeData(t *models.Type) *TypeData {
+	data := &TypeData{
+		Name:     t.Name,
+		GoName:   formatNodeName(t.Name),
+		BaseType: t.BaseType.String(),
+		Format:   t.Format,
+		Units:    t.Units,
+	}
+	if t.Enum != nil {
+		data.Enum = &EnumData{BaseType: t.Enum.BaseType.String()}
+		for _, value := range t.Enum.Values {
+			data.Enum.Values = append(data.Enum.Values, NamedNumberData{Name: value.Name, Value: value.Value})
+		}
+		// Named-number order is part of the SMI definition for Bits, but
+		// Enumeration values carry no such guarantee from gosmi, so sort by
+		// value to keep output stable regardless of declaration order.
+		sort.SliceStable(data.Enum.Values, func(i, j int) bool {
+			return data.Enum.Values[i].Value < data.Enum.Values[j].Value
+		})
+	}
+	for _, r := range t.Ranges {
+		data.Ranges = append(data.Ranges, RangeData{BaseType: r.BaseType.String(), MinValue: r.MinValue, MaxValue: r.MaxValue})
+	}
+	sort.SliceStable(data.Ranges, func(i, j int) bool {
+		return data.Ranges[i].MinValue < data.Ranges[j].MinValue
+	})
+	return data
+}
@@ -0,0 +1,111 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mib2go
+
+import (
+	"testing"
+
+	"github.com/sleepinggenius2/gosmi"
+	"github.com/sleepinggenius2/gosmi/models"
+	"github.com/sleepinggenius2/gosmi/types"
+)
+
+func TestSortModulesByName(t *testing.T) {
+	modules := []gosmi.SmiModule{
+		{Module: models.Module{Name: "IF-MIB"}},
+		{Module: models.Module{Name: "SNMPv2-MIB"}},
+		{Module: models.Module{Name: "ACME-MIB"}},
+	}
+
+	sortModules(modules)
+
+	got := make([]string, len(modules))
+	for i, module := range modules {
+		got[i] = module.Name
+	}
+	want := []string{"ACME-MIB", "IF-MIB", "SNMPv2-MIB"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sortModules order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSortNodesByOidThenName(t *testing.T) {
+	node := func(name string, oid ...types.SmiSubId) gosmi.SmiNode {
+		return gosmi.SmiNode{Node: models.Node{Name: name, Oid: types.Oid(oid)}}
+	}
+	nodes := []gosmi.SmiNode{
+		node("ifTable", 1, 3, 6, 1, 2, 1, 2, 2),
+		node("sysDescr", 1, 3, 6, 1, 2, 1, 1, 1),
+		node("ifNumber", 1, 3, 6, 1, 2, 1, 2, 1),
+		// Two nodes sharing an OID fall back to name order.
+		node("ifEntry", 1, 3, 6, 1, 2, 1, 2, 2, 1),
+		node("ifAugEntry", 1, 3, 6, 1, 2, 1, 2, 2, 1),
+	}
+
+	sortNodes(nodes)
+
+	got := make([]string, len(nodes))
+	for i, n := range nodes {
+		got[i] = n.Name
+	}
+	want := []string{"sysDescr", "ifNumber", "ifTable", "ifAugEntry", "ifEntry"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sortNodes order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestNewTypeDataStabilizesEnumAndRangeOrder(t *testing.T) {
+	smiType := &models.Type{
+		Name:     "IfAdminStatus",
+		BaseType: types.BaseTypeEnum,
+		Enum: &models.Enum{
+			BaseType: types.BaseTypeEnum,
+			// Declared out of value order, as gosmi's parser hands them
+			// back in declaration order, not numeric order.
+			Values: []models.NamedNumber{
+				{Name: "down", Value: 2},
+				{Name: "testing", Value: 3},
+				{Name: "up", Value: 1},
+			},
+		},
+		Ranges: []models.Range{
+			{BaseType: types.BaseTypeInteger32, MinValue: 10, MaxValue: 20},
+			{BaseType: types.BaseTypeInteger32, MinValue: 0, MaxValue: 5},
+		},
+	}
+
+	data := newTypeData(smiType)
+
+	wantEnum := []string{"up", "down", "testing"}
+	for i, want := range wantEnum {
+		if data.Enum.Values[i].Name != want {
+			t.Fatalf("Enum.Values = %+v, want names in order %v", data.Enum.Values, wantEnum)
+		}
+	}
+
+	if data.Ranges[0].MinValue != 0 || data.Ranges[1].MinValue != 10 {
+		t.Fatalf("Ranges = %+v, want ascending MinValue", data.Ranges)
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mib2go
+
+import (
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// templateScope controls whether a template is executed once per module
+// (and gets a ModuleData) or once for the whole generation run (and gets a
+// TreeData covering every loaded module).
+type templateScope int
+
+const (
+	scopeModule templateScope = iota
+	scopeTree
+)
+
+// templateSpec is a built-in, named template.
+type templateSpec struct {
+	scope templateScope
+	ext   string
+	text  string
+}
+
+// builtinTemplates is the registry of formats mib2go ships with. "go" is the
+// default and is what the generator has always produced; the others are
+// additional --format choices.
+var builtinTemplates = map[string]templateSpec{
+	"go":                    {scope: scopeModule, ext: ".go", text: goModuleTemplate},
+	"json":                  {scope: scopeTree, ext: ".json", text: jsonTemplate},
+	"markdown":              {scope: scopeModule, ext: ".md", text: markdownTemplate},
+	"notification-receiver": {scope: scopeTree, ext: ".go", text: notificationReceiverTemplate},
+}
+
+// BuiltinTemplateNames returns the names of the templates mib2go ships with,
+// suitable for listing in --help output.
+func BuiltinTemplateNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadTemplate resolves the template to use for a Generator: a built-in name,
+// or a user-supplied file on disk when templatePath is non-empty. A
+// user-supplied template always has module scope.
+func loadTemplate(name, templatePath string) (*template.Template, templateSpec, error) {
+	if templatePath != "" {
+		t, err := template.New(filepath.Base(templatePath)).Funcs(TemplateFuncs()).ParseFiles(templatePath)
+		if err != nil {
+			return nil, templateSpec{}, errors.Wrapf(err, "Parsing template %s", templatePath)
+		}
+		return t, templateSpec{scope: scopeModule, ext: filepath.Ext(templatePath)}, nil
+	}
+
+	if name == "" {
+		name = "go"
+	}
+	spec, ok := builtinTemplates[name]
+	if !ok {
+		return nil, templateSpec{}, errors.Errorf("Unknown template %q", name)
+	}
+	t, err := template.New(name).Funcs(TemplateFuncs()).Parse(spec.text)
+	if err != nil {
+		return nil, templateSpec{}, errors.Wrapf(err, "Parsing built-in template %s", name)
+	}
+	return t, spec, nil
+}
+
+// TreeData is the whole-run template data handed to tree-scoped templates
+// (e.g. "json", "notification-receiver"), covering every loaded module.
+type TreeData struct {
+	PackageName string
+	Modules     []ModuleData
+	Types       []TypeData
+
+	// ModelsImportPath and TypesImportPath mirror ModuleData's fields, for
+	// tree-scoped templates (e.g. a custom Go emitter run over the whole
+	// MIB tree at once).
+	ModelsImportPath string
+	TypesImportPath  string
+}
+
+const goModuleTemplate = `{{define "typeBody" -}}
+BaseType: types.BaseType{{.BaseType}},
+{{if .Enum}}Enum: &models.Enum{
+	BaseType: types.BaseType{{.Enum.BaseType}},
+	Values: []models.NamedNumber{
+{{range .Enum.Values}}		models.NamedNumber{Name: {{printf "%q" .Name}}, Value: {{goLiteral .Value}}},
+{{end}}	},
+},
+{{end}}{{if .Format}}Format: {{printf "%q" .Format}},
+{{end}}Name: {{printf "%q" .Name}},
+{{if .Ranges}}Ranges: []models.Range{
+{{range .Ranges}}	models.Range{BaseType: types.BaseType{{.BaseType}}, MinValue: {{goLiteral .MinValue}}, MaxValue: {{goLiteral .MaxValue}}},
+{{end}}},
+{{end}}{{if .Units}}Units: {{printf "%q" .Units}},
+{{end -}}
+{{end -}}
+type {{.VarName}} struct {
+{{range .Nodes}}	{{.GoName}}	{{.Package}}.{{.Kind}}Node
+{{end}}}
+
+var {{.GoName}} = {{.VarName}} {
+{{range .Nodes}}	{{.GoName}}:	{{.VarName}},
+{{end}}}
+
+{{range .Nodes}}var {{.VarName}} = {{.Package}}.{{.Kind}}Node{
+	BaseNode: models.BaseNode{
+		Name: {{printf "%q" .Name}},
+		Oid: {{goLiteral .Oid}},
+		OidFormatted: {{printf "%q" .OidFormatted}},
+		OidLen: {{.OidLen}},
+	},
+{{if .Type}}{{if .Type.Shared}}	Type: {{.Type.GoName}}Type,
+{{else}}	Type: models.Type{
+{{template "typeBody" .Type}}	},
+{{end}}{{end}}{{if .RowVarName}}	Row: {{.RowVarName}},
+{{end}}{{if .Columns}}	Columns: []models.ColumnNode{
+{{range .Columns}}		{{.}},
+{{end}}	},
+	Index: []models.ColumnNode{
+{{range .Index}}		{{.}},
+{{end}}	},
+{{end}}{{if .Objects}}	Objects: []models.ScalarNode{
+{{range .Objects}}{{if .IsPlain}}		{{.VarName}},
+{{else}}		models.ScalarNode({{.VarName}}),
+{{end}}{{end}}	},
+{{end}}{{if .Group}}	Members: []models.ScalarNode{
+{{range .Group.Members}}{{if .IsPlain}}		{{.VarName}},
+{{else}}		models.ScalarNode({{.VarName}}),
+{{end}}{{end}}	},
+{{end}}{{if .Compliance}}	Mandatory: []conformance.GroupNode{
+{{range .Compliance.Mandatory}}		{{.}},
+{{end}}	},
+{{if .Compliance.Optional}}	Optional: []conformance.GroupNode{
+{{range .Compliance.Optional}}		{{.}},
+{{end}}	},
+{{end}}{{if .Compliance.Refinements}}	Refinements: []conformance.Refinement{
+{{range .Compliance.Refinements}}		conformance.Refinement{
+{{if .Object.IsPlain}}			Object: {{.Object.VarName}},
+{{else}}			Object: models.ScalarNode({{.Object.VarName}}),
+{{end}}			MinAccess: types.Access{{.MinAccess}},
+{{if .Type}}			Type: &models.Type{
+{{template "typeBody" .Type}}			},
+{{end}}		},
+{{end}}	},
+{{end}}{{end}}{{if .Capabilities}}	Variations: []conformance.Variation{
+{{range .Capabilities.Variations}}		conformance.Variation{ {{if .Object.IsPlain}}Object: {{.Object.VarName}}{{else}}Object: models.ScalarNode({{.Object.VarName}}){{end}}, Access: types.Access{{.Access}}},
+{{end}}	},
+{{end}}}
+
+{{end}}`
+
+const goTypesTemplate = `
+{{define "typeBody" -}}
+BaseType: types.BaseType{{.BaseType}},
+{{if .Enum}}Enum: &models.Enum{
+	BaseType: types.BaseType{{.Enum.BaseType}},
+	Values: []models.NamedNumber{
+{{range .Enum.Values}}		models.NamedNumber{Name: {{printf "%q" .Name}}, Value: {{goLiteral .Value}}},
+{{end}}	},
+},
+{{end}}{{if .Format}}Format: {{printf "%q" .Format}},
+{{end}}Name: {{printf "%q" .Name}},
+{{if .Ranges}}Ranges: []models.Range{
+{{range .Ranges}}	models.Range{BaseType: types.BaseType{{.BaseType}}, MinValue: {{goLiteral .MinValue}}, MaxValue: {{goLiteral .MaxValue}}},
+{{end}}},
+{{end}}{{if .Units}}Units: {{printf "%q" .Units}},
+{{end -}}
+{{end}}
+{{range .}}var {{.GoName}}Type = models.Type{
+{{template "typeBody" .}}}
+
+{{end}}`
+
+const jsonTemplate = `{{define "jsonType" -}}
+{
+            "name": {{jsonString .Name}},
+            "baseType": {{jsonString .BaseType}},
+            "shared": {{.Shared}}{{if .Format}},
+            "format": {{jsonString .Format}}{{end}}{{if .Units}},
+            "units": {{jsonString .Units}}{{end}}{{if .Enum}},
+            "enum": {
+              "baseType": {{jsonString .Enum.BaseType}},
+              "values": [{{range $k, $v := .Enum.Values}}{{if $k}}, {{end}}{"name": {{jsonString $v.Name}}, "value": {{$v.Value}}}{{end}}]
+            }{{end}}{{if .Ranges}},
+            "ranges": [{{range $k, $r := .Ranges}}{{if $k}}, {{end}}{"baseType": {{jsonString $r.BaseType}}, "min": {{$r.MinValue}}, "max": {{$r.MaxValue}}}{{end}}]{{end}}
+          }
+{{- end}}
+{
+  "package": {{jsonString .PackageName}},
+  "modules": [
+{{range $i, $m := .Modules}}{{if $i}},
+{{end}}    {
+      "name": {{jsonString $m.Name}},
+      "nodes": [
+{{range $j, $n := $m.Nodes}}{{if $j}},
+{{end}}        {
+          "name": {{jsonString $n.Name}},
+          "kind": {{jsonString $n.Kind}},
+          "oid": {{jsonString (renderOID $n)}}{{if $n.Description}},
+          "description": {{jsonString $n.Description}}{{end}}{{if $n.Type}},
+          "type": {{template "jsonType" $n.Type}}{{end}}{{if $n.RowVarName}},
+          "row": {{jsonString $n.RowVarName}}{{end}}{{if $n.Columns}},
+          "columns": [{{range $k, $c := $n.Columns}}{{if $k}}, {{end}}{{jsonString $c}}{{end}}]{{end}}{{if $n.Index}},
+          "index": [{{range $k, $c := $n.Index}}{{if $k}}, {{end}}{{jsonString $c}}{{end}}]{{end}}{{if $n.Objects}},
+          "objects": [{{range $k, $o := $n.Objects}}{{if $k}}, {{end}}{{jsonString $o.VarName}}{{end}}]{{end}}
+        }
+{{end}}      ]
+    }
+{{end}}  ]
+}
+`
+
+const markdownTemplate = `# {{.Name}}
+
+| Name | Kind | OID | Description |
+| --- | --- | --- | --- |
+{{range .Nodes}}| {{.Name}} | {{.Kind}} | ` + "`{{renderOID .}}`" + ` | {{markdownCell .Description}} |
+{{end}}
+`
+
+const notificationReceiverTemplate = `// Code generated by mib2go. DO NOT EDIT.
+package {{.PackageName}}
+
+import (
+	{{printf "%q" .TypesImportPath}}
+)
+
+// HandleNotification dispatches an incoming SNMP notification, identified by
+// its OID, to the case matching the MIB it was generated from. Fill in each
+// case with the receiver's actual handling logic.
+func HandleNotification(oid types.Oid, varbinds map[string]interface{}) error {
+	switch oid.String() {
+{{range .Modules}}{{range .Nodes}}{{if eq .Kind "Notification"}}	case {{printf "%q" (renderOID .)}}: // {{.Name}}
+		return nil
+{{end}}{{end}}{{end}}	}
+	return nil
+}
+`
@@ -0,0 +1,127 @@
+// Copyright © 2017 sleepinggenius2 <sleepinggenius2@users.noreply.github.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mib2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONTemplateCarriesTypeAndDescription(t *testing.T) {
+	snmpv2 := snmpv2MibFixture()
+	snmpv2.Nodes[0].Description = "The sysDescr object."
+	ifMib := ifMibFixture()
+
+	tmpl, _, err := loadTemplate("json", "")
+	if err != nil {
+		t.Fatalf("loadTemplate: %v", err)
+	}
+
+	data := TreeData{PackageName: "mibs", Modules: []ModuleData{snmpv2, ifMib}}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	var decoded struct {
+		Modules []struct {
+			Nodes []struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+				Type        *struct {
+					BaseType string `json:"baseType"`
+				} `json:"type"`
+				Columns []string `json:"columns"`
+				Index   []string `json:"index"`
+			} `json:"nodes"`
+		} `json:"modules"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	sysDescr := decoded.Modules[0].Nodes[0]
+	if sysDescr.Description != "The sysDescr object." {
+		t.Errorf("sysDescr description = %q, want %q", sysDescr.Description, "The sysDescr object.")
+	}
+	if sysDescr.Type == nil || sysDescr.Type.BaseType != "OctetString" {
+		t.Errorf("sysDescr type = %+v, want baseType OctetString", sysDescr.Type)
+	}
+
+	found := false
+	for _, node := range decoded.Modules[1].Nodes {
+		if node.Name != "ifEntry" {
+			continue
+		}
+		found = true
+		if len(node.Columns) != 2 || len(node.Index) != 1 {
+			t.Errorf("ifEntry columns/index = %+v, want 2 columns and 1 index entry", node)
+		}
+	}
+	if !found {
+		t.Fatalf("ifEntry node missing from output")
+	}
+}
+
+func TestNotificationReceiverTemplateDispatchesOnOidAndHonorsTypesImportPath(t *testing.T) {
+	snmpv2 := snmpv2MibFixture()
+
+	tmpl, _, err := loadTemplate("notification-receiver", "")
+	if err != nil {
+		t.Fatalf("loadTemplate: %v", err)
+	}
+
+	data := TreeData{PackageName: "mibs", Modules: []ModuleData{snmpv2}, TypesImportPath: "example.com/forked/gosmi/types"}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"example.com/forked/gosmi/types"`) {
+		t.Errorf("notification-receiver output missing configured TypesImportPath:\n%s", out)
+	}
+	if !strings.Contains(out, `case "1.3.6.1.6.3.1.1.5.1": // coldStart`) {
+		t.Errorf("notification-receiver output missing coldStart case:\n%s", out)
+	}
+}
+
+func TestMarkdownTemplateContainsDescription(t *testing.T) {
+	snmpv2 := snmpv2MibFixture()
+	snmpv2.Nodes[0].Description = "Line one.\nLine two with a | pipe."
+
+	tmpl, _, err := loadTemplate("markdown", "")
+	if err != nil {
+		t.Fatalf("loadTemplate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, snmpv2); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "Line one. Line two with a \\| pipe.") {
+		t.Errorf("markdown output missing collapsed, escaped description:\n%s", out)
+	}
+}